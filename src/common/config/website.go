@@ -0,0 +1,140 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config describes the per-website crawl configuration the extractor
+// is given, typically loaded from the extractor's configuration file.
+package config
+
+import "regexp"
+
+// Website holds the crawl configuration for a single website the extractor
+// is configured to crawl.
+type Website struct {
+	// Identifier uniquely names this website across the extractor's
+	// configuration, the database, and the frontier store.
+	Identifier string
+
+	// Selectors are the CSS selectors used to locate an article's content,
+	// title, date and optional fields within a page.
+	Selectors WebsiteSelectors
+
+	// Filters restricts and excludes which discovered URLs are crawled for
+	// this website. A nil Filters crawls every discovered URL.
+	Filters *WebsiteFilters
+
+	// Query controls how a URL's query string is rewritten before being
+	// queued, so the same page isn't crawled multiple times under
+	// cosmetically different URLs. A nil Query leaves query strings as-is.
+	Query *WebsiteQuery
+
+	// DateFormat is the Go reference-time layout used to parse the date text
+	// found via Selectors.Date.
+	DateFormat string
+
+	// IncludeRelated opts this website into fetching the related assets
+	// (images, scripts, stylesheets) an article references, alongside the
+	// article itself.
+	IncludeRelated bool
+
+	// Fallback names the structured-data extraction strategy to fall back to
+	// when Selectors doesn't match a page, one of the Fallback* constants
+	// declared alongside the extractor.
+	Fallback string
+
+	// Archive configures WARC archiving of this website's crawled resources.
+	// A nil Archive disables archiving.
+	Archive *WebsiteArchive
+
+	// Frontier configures the persistent crawl frontier's retry behaviour for
+	// this website. A nil Frontier falls back to the extractor's defaults.
+	Frontier *WebsiteFrontier
+
+	// Sinks configures the optional article sinks saved articles are fanned
+	// out to, alongside the database. A nil Sinks only writes to the
+	// database.
+	Sinks *WebsiteSinks
+}
+
+// WebsiteSelectors holds the CSS selectors used to extract an article's
+// fields from a page. Content, Title and Date are required for a page to be
+// recognised as an article; Description, Author and Thumbnail are optional.
+type WebsiteSelectors struct {
+	Content     string
+	Title       string
+	Date        string
+	Description string
+	Author      string
+	Thumbnail   string
+}
+
+// WebsiteFilters restricts and excludes which discovered URLs are crawled. A
+// nil Restrict matches every URL; a nil Exclude matches none.
+type WebsiteFilters struct {
+	Restrict *regexp.Regexp
+	Exclude  *regexp.Regexp
+}
+
+// WebsiteQuery controls how a URL's query string is rewritten before being
+// queued. If IgnoreAll is true, every key is dropped except the ones listed
+// in Except; if false, every key is kept except the ones listed in Except.
+type WebsiteQuery struct {
+	IgnoreAll bool
+	Except    []string
+}
+
+// WebsiteArchive configures WARC archiving for a website.
+type WebsiteArchive struct {
+	// Enabled turns archiving on or off.
+	Enabled bool
+
+	// OutputDir is the directory WARC files are written to.
+	OutputDir string
+
+	// RotateSize is the approximate size, in bytes, a WARC file is allowed
+	// to grow to before a new one is started. 0 falls back to the archive
+	// writer's own default.
+	RotateSize int64
+}
+
+// WebsiteFrontier configures the persistent crawl frontier's retry behaviour
+// for a website.
+type WebsiteFrontier struct {
+	// MaxRetries is how many times a failed URL is retried before being
+	// given up on. 0 falls back to the extractor's default.
+	MaxRetries int
+}
+
+// WebsiteSinks configures the optional article sinks a website fans saved
+// articles out to, alongside the database.
+type WebsiteSinks struct {
+	// JSONLPath, if set, appends every saved article as a line of JSON to
+	// the file at this path.
+	JSONLPath string
+
+	// FeedDir, if set, keeps an Atom feed of this website's most recent
+	// articles in this directory.
+	FeedDir string
+
+	// FeedMaxEntries caps how many articles the Atom feed keeps. 0 falls
+	// back to the extractor's default of 50.
+	FeedMaxEntries int
+
+	// WebhookURL, if set, POSTs every saved article as JSON to this URL,
+	// signed with WebhookSecret.
+	WebhookURL string
+
+	// WebhookSecret is the HMAC-SHA256 key used to sign webhook requests.
+	WebhookSecret string
+}
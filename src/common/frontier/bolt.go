@@ -0,0 +1,197 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package frontier
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// bucketName is the single BoltDB bucket the frontier is kept in. Entries are
+// keyed by "<website_id>\x00<url>" so Load can range over a single website's
+// entries with a key prefix scan.
+var bucketName = []byte("frontier")
+
+// BoltStore implements Store on top of a local BoltDB file, for single-binary
+// deploys that would rather not depend on a PostgreSQL server.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if needed) a BoltDB file at path and ensures
+// the frontier bucket exists.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// key builds the composite key an entry is stored under.
+func key(websiteID, url string) []byte {
+	return append(append([]byte(websiteID), 0), []byte(url)...)
+}
+
+// Load implements Store.Load
+func (s *BoltStore) Load(websiteID string) ([]Entry, error) {
+	var entries []Entry
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketName).Cursor()
+		prefix := append([]byte(websiteID), 0)
+
+		for k, v := c.Seek(prefix); k != nil && hasPrefix(k, prefix); k, v = c.Next() {
+			var e Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			entries = append(entries, e)
+		}
+
+		return nil
+	})
+
+	return entries, err
+}
+
+// MarkPending implements Store.MarkPending
+func (s *BoltStore) MarkPending(websiteID, url string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		k := key(websiteID, url)
+
+		if b.Get(k) != nil {
+			// Already known; MarkPending must be a no-op in that case.
+			return nil
+		}
+
+		return putEntry(b, k, Entry{
+			URL: url, WebsiteID: websiteID, Status: StatusPending,
+			FirstSeen: time.Now().UTC(), LastAttempt: time.Now().UTC(),
+		})
+	})
+}
+
+// MarkFetched implements Store.MarkFetched
+func (s *BoltStore) MarkFetched(websiteID, url string) error {
+	return s.update(websiteID, url, func(e *Entry) {
+		e.Status = StatusFetched
+		e.LastAttempt = time.Now().UTC()
+	})
+}
+
+// MarkFailed implements Store.MarkFailed
+func (s *BoltStore) MarkFailed(websiteID, url, cause string, maxRetries int) error {
+	return s.update(websiteID, url, func(e *Entry) {
+		e.Retries++
+		e.Cause = cause
+		e.LastAttempt = time.Now().UTC()
+		if e.Retries >= maxRetries {
+			e.Status = StatusSkipped
+		} else {
+			e.Status = StatusPending
+		}
+	})
+}
+
+// Stats implements Store.Stats
+func (s *BoltStore) Stats(websiteID string) (Stats, error) {
+	stats := Stats{WebsiteID: websiteID}
+
+	entries, err := s.Load(websiteID)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, e := range entries {
+		switch e.Status {
+		case StatusPending:
+			stats.Pending++
+		case StatusFetched:
+			stats.Fetched++
+		case StatusFailed:
+			stats.Failed++
+		case StatusSkipped:
+			stats.Skipped++
+		}
+	}
+
+	return stats, nil
+}
+
+// Close implements Store.Close
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// update loads the entry at (websiteID, url), applies mutate, and writes it
+// back. It is a no-op if the entry doesn't exist.
+func (s *BoltStore) update(websiteID, url string, mutate func(e *Entry)) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		k := key(websiteID, url)
+
+		raw := b.Get(k)
+		if raw == nil {
+			return nil
+		}
+
+		var e Entry
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		mutate(&e)
+
+		return putEntry(b, k, e)
+	})
+}
+
+// putEntry marshals e and stores it under k in b.
+func putEntry(b *bolt.Bucket, k []byte, e Entry) error {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(k, raw)
+}
+
+// hasPrefix reports whether k starts with prefix.
+func hasPrefix(k, prefix []byte) bool {
+	if len(k) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if k[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package frontier persists the set of URLs a crawl has seen, so a crawler
+// can be restarted without losing track of what it already fetched, what
+// still needs fetching, and what failed and should be retried. It is the
+// on-disk counterpart to gocrawl's in-memory queue.
+package frontier
+
+import (
+	"math"
+	"time"
+)
+
+// Status is the fetch status of a single frontier entry.
+type Status string
+
+// The set of statuses a frontier entry can be in over its lifetime:
+// Pending (queued, not fetched yet) -> Fetched, or, on a failed attempt,
+// straight back to Pending with its retry count incremented, until MaxRetries
+// is reached, at which point it becomes Skipped. StatusFailed is not written
+// by PostgresStore or BoltStore, so their Stats.Failed count is always 0.
+const (
+	StatusPending Status = "pending"
+	StatusFetched Status = "fetched"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Entry is a single URL tracked by the frontier for a given website.
+type Entry struct {
+	URL         string
+	WebsiteID   string
+	Status      Status
+	FirstSeen   time.Time
+	LastAttempt time.Time
+	Retries     int
+	Cause       string
+}
+
+// Stats summarises the frontier's content for a single website.
+type Stats struct {
+	WebsiteID string `json:"website_id"`
+	Pending   int    `json:"pending"`
+	Fetched   int    `json:"fetched"`
+	Failed    int    `json:"failed"`
+	Skipped   int    `json:"skipped"`
+}
+
+// Store persists frontier entries. PostgresStore and BoltStore are the two
+// implementations shipped with the extractor; PostgresStore is used when the
+// extractor already runs against a PostgreSQL database, BoltStore is offered
+// for single-binary deploys that don't want a database dependency.
+type Store interface {
+	// Load returns every entry recorded for websiteID, so the caller can
+	// rebuild its in-memory "already seen" set and reseed the crawl queue
+	// from whatever is still Pending.
+	Load(websiteID string) ([]Entry, error)
+
+	// MarkPending records url as queued for websiteID. It is a no-op if the
+	// URL is already known, so Enqueued can call it unconditionally.
+	MarkPending(websiteID, url string) error
+
+	// MarkFetched records url as successfully fetched.
+	MarkFetched(websiteID, url string) error
+
+	// MarkFailed increments url's retry count and records cause. Once the
+	// retry count reaches maxRetries, the entry moves to Skipped instead of
+	// back to Pending.
+	MarkFailed(websiteID, url, cause string, maxRetries int) error
+
+	// Stats returns the current counts per status for websiteID.
+	Stats(websiteID string) (Stats, error)
+
+	// Close releases any resource held by the store.
+	Close() error
+}
+
+// Backoff returns how long to wait before the next attempt at a URL that has
+// already failed retries times, using a capped exponential backoff.
+func Backoff(retries int) time.Duration {
+	const base = 30 * time.Second
+	const max = 2 * time.Hour
+
+	d := time.Duration(math.Pow(2, float64(retries))) * base
+	if d > max || d <= 0 {
+		return max
+	}
+
+	return d
+}
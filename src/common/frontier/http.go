@@ -0,0 +1,42 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package frontier
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// StatsHandler returns an http.Handler that reports frontier.Stats for every
+// website in websiteIDs, as a JSON array, so the frontier's progress can be
+// monitored from outside the crawler process.
+func StatsHandler(store Store, websiteIDs []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		all := make([]Stats, 0, len(websiteIDs))
+
+		for _, id := range websiteIDs {
+			stats, err := store.Stats(id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			all = append(all, stats)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(all)
+	})
+}
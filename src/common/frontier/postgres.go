@@ -0,0 +1,161 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package frontier
+
+import (
+	"database/sql"
+)
+
+// schema for the frontier_urls table, created by the same migration process
+// the rest of the database package uses:
+//
+//	CREATE TABLE IF NOT EXISTS frontier_urls (
+//		website_id   TEXT NOT NULL,
+//		url          TEXT NOT NULL,
+//		status       TEXT NOT NULL,
+//		first_seen   TIMESTAMP WITH TIME ZONE NOT NULL,
+//		last_attempt TIMESTAMP WITH TIME ZONE NOT NULL,
+//		retries      INTEGER NOT NULL DEFAULT 0,
+//		cause        TEXT NOT NULL DEFAULT '',
+//		PRIMARY KEY (website_id, url)
+//	);
+
+// PostgresStore implements Store on top of the same PostgreSQL database the
+// extractor already persists articles to.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore wraps an already-open database connection into a
+// PostgresStore. It doesn't take ownership of db: Close is a no-op, since the
+// connection is expected to be shared with and closed by database.Database.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Load implements Store.Load
+func (s *PostgresStore) Load(websiteID string) ([]Entry, error) {
+	rows, err := s.db.Query(
+		`SELECT url, status, first_seen, last_attempt, retries, cause
+		 FROM frontier_urls WHERE website_id = $1`,
+		websiteID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		e := Entry{WebsiteID: websiteID}
+		if err := rows.Scan(&e.URL, &e.Status, &e.FirstSeen, &e.LastAttempt, &e.Retries, &e.Cause); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// MarkPending implements Store.MarkPending
+func (s *PostgresStore) MarkPending(websiteID, url string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO frontier_urls (website_id, url, status, first_seen, last_attempt)
+		 VALUES ($1, $2, $3, now(), now())
+		 ON CONFLICT (website_id, url) DO NOTHING`,
+		websiteID, url, StatusPending,
+	)
+
+	return err
+}
+
+// MarkFetched implements Store.MarkFetched
+func (s *PostgresStore) MarkFetched(websiteID, url string) error {
+	_, err := s.db.Exec(
+		`UPDATE frontier_urls SET status = $1, last_attempt = now()
+		 WHERE website_id = $2 AND url = $3`,
+		StatusFetched, websiteID, url,
+	)
+
+	return err
+}
+
+// MarkFailed implements Store.MarkFailed
+func (s *PostgresStore) MarkFailed(websiteID, url, cause string, maxRetries int) error {
+	var retries int
+	if err := s.db.QueryRow(
+		`SELECT retries FROM frontier_urls WHERE website_id = $1 AND url = $2`,
+		websiteID, url,
+	).Scan(&retries); err != nil {
+		return err
+	}
+
+	retries++
+	status := StatusPending
+	if retries >= maxRetries {
+		status = StatusSkipped
+	}
+
+	_, err := s.db.Exec(
+		`UPDATE frontier_urls
+		 SET status = $1, retries = $2, cause = $3, last_attempt = now()
+		 WHERE website_id = $4 AND url = $5`,
+		status, retries, cause, websiteID, url,
+	)
+
+	return err
+}
+
+// Stats implements Store.Stats
+func (s *PostgresStore) Stats(websiteID string) (Stats, error) {
+	stats := Stats{WebsiteID: websiteID}
+
+	rows, err := s.db.Query(
+		`SELECT status, count(*) FROM frontier_urls WHERE website_id = $1 GROUP BY status`,
+		websiteID,
+	)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status Status
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return stats, err
+		}
+
+		switch status {
+		case StatusPending:
+			stats.Pending = count
+		case StatusFetched:
+			stats.Fetched = count
+		case StatusFailed:
+			stats.Failed = count
+		case StatusSkipped:
+			stats.Skipped = count
+		}
+	}
+
+	return stats, rows.Err()
+}
+
+// Close implements Store.Close
+// PostgresStore doesn't own the connection it was handed, so this is a no-op.
+func (s *PostgresStore) Close() error {
+	return nil
+}
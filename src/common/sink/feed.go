@@ -0,0 +1,119 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// atomFeed and atomEntry are a minimal subset of the Atom 1.0 syndication
+// format (RFC 4287), just enough to publish a feed of recent articles.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Updated string `xml:"updated"`
+	Author  string `xml:"author>name,omitempty"`
+	Link    struct {
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+	Summary string `xml:"summary"`
+}
+
+// FeedSink keeps the MaxEntries most recent articles per website as an Atom
+// feed file on disk, named "<website-id>.atom" inside Dir.
+type FeedSink struct {
+	mu         sync.Mutex
+	dir        string
+	maxEntries int
+	recent     map[string][]Article
+}
+
+// NewFeedSink returns a FeedSink writing into dir, keeping at most maxEntries
+// articles per website.
+func NewFeedSink(dir string, maxEntries int) *FeedSink {
+	return &FeedSink{
+		dir:        dir,
+		maxEntries: maxEntries,
+		recent:     make(map[string][]Article),
+	}
+}
+
+// Save implements ArticleSink.Save
+func (s *FeedSink) Save(ctx context.Context, a Article) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	articles := append([]Article{a}, s.recent[a.WebsiteID]...)
+	if len(articles) > s.maxEntries {
+		articles = articles[:s.maxEntries]
+	}
+	s.recent[a.WebsiteID] = articles
+
+	return s.writeFeed(a.WebsiteID, articles)
+}
+
+// writeFeed renders articles as an Atom feed and writes it to
+// "<websiteID>.atom" in s.dir.
+func (s *FeedSink) writeFeed(websiteID string, articles []Article) error {
+	feed := atomFeed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		ID:      "informo-extractor:" + websiteID,
+		Title:   websiteID,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	for _, a := range articles {
+		entry := atomEntry{
+			ID:      a.URL.String(),
+			Title:   a.Title,
+			Updated: a.PublishedAt.UTC().Format(time.RFC3339),
+		}
+		entry.Link.Href = a.URL.String()
+		if a.Author != nil {
+			entry.Author = *a.Author
+		}
+		if a.Description != nil {
+			entry.Summary = *a.Description
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(
+		filepath.Join(s.dir, websiteID+".atom"),
+		append([]byte(xml.Header), data...),
+		0644,
+	)
+}
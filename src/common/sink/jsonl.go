@@ -0,0 +1,51 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// JSONLSink appends one JSON-encoded Article per line to a file on disk, so
+// downstream systems (search indexers, static-site generators) can tail or
+// batch-import the extractor's output without touching the database.
+type JSONLSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONLSink returns a JSONLSink that appends to the file at path, creating
+// it if it doesn't exist yet.
+func NewJSONLSink(path string) *JSONLSink {
+	return &JSONLSink{path: path}
+}
+
+// Save implements ArticleSink.Save
+func (s *JSONLSink) Save(ctx context.Context, a Article) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(a)
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sink defines the ArticleSink interface through which the extractor
+// hands off a freshly-crawled article to whatever is consuming it, along with
+// a few ready-to-use implementations (JSON-lines file, Atom feed, signed
+// webhook) that complement the existing PostgreSQL persistence.
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// Article is the sink-facing representation of a crawled news item: the same
+// fields crawler.Extender.Visit extracts, plus the metadata a downstream
+// consumer needs to tell articles apart and trace them back to the crawl that
+// produced them.
+type Article struct {
+	WebsiteID   string
+	URL         *url.URL
+	Title       string
+	Description *string
+	Content     string
+	Author      *string
+	PublishedAt time.Time
+	CrawledAt   time.Time
+}
+
+// articleJSON is Article's wire representation: url.URL doesn't marshal to a
+// plain string on its own, so Article implements json.Marshaler instead of
+// relying on the default struct encoding.
+type articleJSON struct {
+	WebsiteID   string    `json:"website_id"`
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	Description *string   `json:"description,omitempty"`
+	Content     string    `json:"content"`
+	Author      *string   `json:"author,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	CrawledAt   time.Time `json:"crawled_at"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a Article) MarshalJSON() ([]byte, error) {
+	return json.Marshal(articleJSON{
+		WebsiteID:   a.WebsiteID,
+		URL:         a.URL.String(),
+		Title:       a.Title,
+		Description: a.Description,
+		Content:     a.Content,
+		Author:      a.Author,
+		PublishedAt: a.PublishedAt,
+		CrawledAt:   a.CrawledAt,
+	})
+}
+
+// ArticleSink receives every article the extractor saves. crawler.databaseSink
+// is one implementation, adapting database.Database; JSONLSink, FeedSink and
+// WebhookSink are the others shipped with the extractor.
+type ArticleSink interface {
+	Save(ctx context.Context, a Article) error
+}
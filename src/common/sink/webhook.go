@@ -0,0 +1,81 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// signatureHeader carries "sha256=" followed by the hex-encoded HMAC-SHA256
+// of the request body, so the receiving end can authenticate the payload came from this extractor.
+const signatureHeader = "X-Informo-Signature"
+
+// WebhookSink POSTs every article as JSON to a configured URL, signing the
+// body with HMAC-SHA256 so the receiving end can authenticate it.
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookSink returns a WebhookSink that POSTs to url, signing each
+// request body with secret.
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Save implements ArticleSink.Save
+func (s *WebhookSink) Save(ctx context.Context, a Article) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(signatureHeader, "sha256="+signature)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("webhook sink: %s returned %s", s.url, res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package warc implements a minimal writer for the WARC/1.0 file format, as
+// described at https://iipc.github.io/warc-specifications/specifications/warc-format/warc-1.0/,
+// so crawled resources can be archived alongside the data the extractor
+// persists to the database.
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultRotateSize (1 GiB) is used when a Writer is instantiated with a
+// rotateSize of 0, which is what the website configuration's Archive
+// RotateSize defaults to.
+const defaultRotateSize int64 = 1 << 30
+
+// Writer appends WARC records to a gzip-compressed file on disk, rotating to
+// a new file once the current one grows past rotateSize. A Writer is bound to
+// a single website, so that archives can be told apart on disk and replayed
+// independently.
+//
+// A Writer is safe for concurrent use.
+type Writer struct {
+	mu          sync.Mutex
+	dir         string
+	website     string
+	rotateSize  int64
+	info        map[string]string
+	seq         int
+	file        *os.File
+	gz          *gzip.Writer
+	writtenSize int64
+}
+
+// NewWriter creates the output directory if needed and opens the first WARC
+// file for the given website, writing a warcinfo record built from info
+// before returning. A rotateSize of 0 falls back to defaultRotateSize. info
+// is retained and re-emitted as a fresh warcinfo record every time rotate
+// opens a new file, so every segment of the archive is spec-compliant on its
+// own.
+func NewWriter(dir, website string, rotateSize int64, info map[string]string) (*Writer, error) {
+	if rotateSize <= 0 {
+		rotateSize = defaultRotateSize
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	w := &Writer{
+		dir:        dir,
+		website:    website,
+		rotateSize: rotateSize,
+		info:       info,
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.rotate(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// WriteRequest appends a `request` record built from req to the archive,
+// using target as the WARC-Target-URI so it can be paired with the matching
+// `response` record.
+func (w *Writer) WriteRequest(target string, req *http.Request) error {
+	block, err := httputil.DumpRequestOut(req, false)
+	if err != nil {
+		return err
+	}
+
+	return w.writeRecord("request", target, "application/http; msgtype=request", block)
+}
+
+// WriteResponse appends a `response` record to the archive, using target as
+// the WARC-Target-URI. body is the response's raw bytes, already read off the
+// wire by the caller, since http.Response.Body can only be consumed once.
+func (w *Writer) WriteResponse(target string, res *http.Response, body []byte) error {
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	block, err := httputil.DumpResponse(res, true)
+	if err != nil {
+		return err
+	}
+
+	return w.writeRecord("response", target, "application/http; msgtype=response", block)
+}
+
+// Close flushes and closes the WARC file currently being written to.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.closeCurrent()
+}
+
+// writeWarcinfoLocked appends the warcinfo record every WARC file must start
+// with, describing the crawler and the configuration it is running with. It
+// assumes w.mu is already held, since it is only ever called from rotate.
+func (w *Writer) writeWarcinfoLocked() error {
+	var buf bytes.Buffer
+	for k, v := range w.info {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
+	}
+
+	return w.writeRecordLocked("warcinfo", "", "application/warc-fields", buf.Bytes())
+}
+
+// writeRecord writes a single WARC record to the current file, rotating to a
+// new one first if the current file has grown past rotateSize.
+func (w *Writer) writeRecord(recordType, target, contentType string, block []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.writtenSize >= w.rotateSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	return w.writeRecordLocked(recordType, target, contentType, block)
+}
+
+// writeRecordLocked does the actual work of writeRecord. It assumes w.mu is
+// already held, so rotate can call it directly to emit a file's warcinfo
+// record without re-entering the lock writeRecord itself holds.
+func (w *Writer) writeRecordLocked(recordType, target, contentType string, block []byte) error {
+	id, err := newRecordID()
+	if err != nil {
+		return err
+	}
+
+	var header bytes.Buffer
+	fmt.Fprint(&header, "WARC/1.0\r\n")
+	fmt.Fprintf(&header, "WARC-Type: %s\r\n", recordType)
+	fmt.Fprintf(&header, "WARC-Record-ID: %s\r\n", id)
+	fmt.Fprintf(&header, "WARC-Date: %s\r\n", time.Now().UTC().Format(time.RFC3339Nano))
+	if target != "" {
+		fmt.Fprintf(&header, "WARC-Target-URI: %s\r\n", target)
+	}
+	fmt.Fprintf(&header, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(&header, "Content-Length: %d\r\n", len(block))
+	fmt.Fprint(&header, "\r\n")
+
+	if _, err := w.gz.Write(header.Bytes()); err != nil {
+		return err
+	}
+
+	if _, err := w.gz.Write(block); err != nil {
+		return err
+	}
+
+	if _, err := w.gz.Write([]byte("\r\n\r\n")); err != nil {
+		return err
+	}
+
+	if err := w.gz.Flush(); err != nil {
+		return err
+	}
+
+	// rotateSize bounds the compressed file on disk, so track its actual
+	// size rather than the uncompressed bytes handed to the gzip writer.
+	fi, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	w.writtenSize = fi.Size()
+
+	return nil
+}
+
+// rotate closes the file currently being written to, if any, opens a new one
+// named crawl-<website>-<timestamp>-<seq>.warc.gz in dir, and writes a fresh
+// warcinfo record to it, so every segment is replayable on its own. It
+// assumes w.mu is already held by the caller (NewWriter or writeRecord).
+func (w *Writer) rotate() error {
+	if err := w.closeCurrent(); err != nil {
+		return err
+	}
+
+	w.seq++
+	name := fmt.Sprintf(
+		"crawl-%s-%s-%d.warc.gz",
+		w.website, time.Now().UTC().Format("20060102150405"), w.seq,
+	)
+
+	f, err := os.Create(filepath.Join(w.dir, name))
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.gz = gzip.NewWriter(f)
+	w.writtenSize = 0
+
+	return w.writeWarcinfoLocked()
+}
+
+// closeCurrent flushes and closes the file currently being written to, if
+// any. It is a no-op if no file is open yet.
+func (w *Writer) closeCurrent() error {
+	if w.gz == nil {
+		return nil
+	}
+
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+
+	err := w.file.Close()
+	w.gz = nil
+	w.file = nil
+
+	return err
+}
+
+// newRecordID generates a random, RFC 4122 version 4 UUID formatted as a WARC
+// record ID (a URN, as required by the spec).
+func newRecordID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf(
+		"<urn:uuid:%x-%x-%x-%x-%x>",
+		b[0:4], b[4:6], b[6:8], b[8:10], b[10:16],
+	), nil
+}
@@ -16,14 +16,23 @@
 package crawler
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	htmlescape "html"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"common/config"
 	"common/database"
+	"common/frontier"
+	"common/sink"
+	"common/warc"
 
 	"github.com/PuerkitoBio/gocrawl"
 	"github.com/PuerkitoBio/goquery"
@@ -37,29 +46,106 @@ import (
 // or abort the process.
 type Extender struct {
 	gocrawl.DefaultExtender
-	db              *database.Database
-	website         *config.Website
-	log             *logrus.Entry
-	visitedArticles map[string]bool
-	errChan         chan error
-	abortChan       chan string
+	db         *database.Database
+	website    *config.Website
+	log        *logrus.Entry
+	frontier   frontier.Store
+	seenMu     sync.Mutex
+	seen       map[string]bool
+	maxRetries int
+	sinks      []sink.ArticleSink
+	archive    *warc.Writer
+	errChan    chan error
+	abortChan  chan string
 }
 
-// NewExtender instantiate an Extender.
-// Returns an error if an issue happened while loading the visited article's URLs
-// from the database.
+// NewExtender instantiates an Extender, along with the list of URLs the
+// frontier store had recorded as still pending for this website, so the
+// caller can reseed gocrawl's queue and resume an interrupted crawl instead
+// of starting over from the configured seeds.
+// Returns an error if an issue happened while loading the frontier for this
+// website.
 func NewExtender(
-	db *database.Database, website *config.Website, log *logrus.Entry,
+	db *database.Database, website *config.Website, store frontier.Store, log *logrus.Entry,
 	errCh chan error, abortCh chan string,
-) (*Extender, error) {
-	// Load the URLs of visited articles from the database so we can use it to
-	// filter the enqueuing process and speed the crawls up.
-	visited, err := db.RetrieveArticleURLsForWebsite(website.Identifier)
+) (*Extender, []*url.URL, error) {
+	entries, err := store.Load(website.Identifier)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	log.Infof("Loaded %d visited URLs for this website", len(visited))
+	seen := make(map[string]bool, len(entries))
+	var pending []*url.URL
+	var deferred int
+	now := time.Now().UTC()
+	for _, e := range entries {
+		seen[e.URL] = true
+
+		if e.Status != frontier.StatusPending {
+			continue
+		}
+
+		// A URL that failed at least once goes straight back to Pending (see
+		// Store.MarkFailed), but it shouldn't be reseeded before its backoff
+		// window has elapsed, or a flaky site gets hammered with retries on
+		// every restart.
+		if e.Retries > 0 && now.Sub(e.LastAttempt) < frontier.Backoff(e.Retries) {
+			deferred++
+			continue
+		}
+
+		if u, err := url.Parse(e.URL); err == nil {
+			pending = append(pending, u)
+		}
+	}
+
+	log.Infof(
+		"Loaded %d frontier entries for this website, %d pending, %d deferred by backoff",
+		len(entries), len(pending), deferred,
+	)
+
+	maxRetries := 5
+	if website.Frontier != nil && website.Frontier.MaxRetries > 0 {
+		maxRetries = website.Frontier.MaxRetries
+	}
+
+	var archiveWriter *warc.Writer
+	if website.Archive != nil && website.Archive.Enabled {
+		archiveWriter, err = warc.NewWriter(
+			website.Archive.OutputDir, website.Identifier, website.Archive.RotateSize,
+			map[string]string{
+				"software":      "informo-extractor",
+				"format":        "WARC File Format 1.0",
+				"isPartOf":      website.Identifier,
+				"conformsTo":    "http://bibnum.bnf.fr/WARC/WARC_ISO_28500_version1_latestdraft.pdf",
+				"robots-policy": "obey",
+			},
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		log.Infof("Archiving crawl output to %s", website.Archive.OutputDir)
+	}
+
+	// The database is always written to; additional sinks are fanned out to
+	// alongside it, according to which ones this website is configured with.
+	sinks := []sink.ArticleSink{&databaseSink{db: db}}
+	if website.Sinks != nil {
+		if website.Sinks.JSONLPath != "" {
+			sinks = append(sinks, sink.NewJSONLSink(website.Sinks.JSONLPath))
+		}
+		if website.Sinks.FeedDir != "" {
+			maxEntries := website.Sinks.FeedMaxEntries
+			if maxEntries <= 0 {
+				maxEntries = 50
+			}
+			sinks = append(sinks, sink.NewFeedSink(website.Sinks.FeedDir, maxEntries))
+		}
+		if website.Sinks.WebhookURL != "" {
+			sinks = append(sinks, sink.NewWebhookSink(website.Sinks.WebhookURL, website.Sinks.WebhookSecret))
+		}
+	}
 
 	// Instantiate the extender.
 	return &Extender{
@@ -67,10 +153,50 @@ func NewExtender(
 		db:              db,
 		website:         website,
 		log:             log,
-		visitedArticles: visited,
+		frontier:        store,
+		seen:            seen,
+		maxRetries:      maxRetries,
+		sinks:           sinks,
+		archive:         archiveWriter,
 		errChan:         errCh,
 		abortChan:       abortCh,
-	}, nil
+	}, pending, nil
+}
+
+// Fetch implements gocrawl.Extender.Fetch
+// Defers to the embedded DefaultExtender to perform the actual HTTP request,
+// then, if archiving is enabled for this website, streams the request and
+// response into the WARC writer before handing the response back to gocrawl.
+// The response's body is read into memory so it can be archived and still be
+// read again further down the pipeline (by Visit, for goquery parsing), since
+// http.Response.Body can only be consumed once.
+func (e *Extender) Fetch(ctx *gocrawl.URLContext, userAgent string, headRequest bool) (*http.Response, error) {
+	res, err := e.DefaultExtender.Fetch(ctx, userAgent, headRequest)
+	if err != nil || e.archive == nil || res == nil {
+		return res, err
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return res, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if res.Request != nil {
+		if err := e.archive.WriteRequest(ctx.URL().String(), res.Request); err != nil {
+			e.log.WithError(err).Warn("Failed to write WARC request record")
+		}
+	}
+	if err := e.archive.WriteResponse(ctx.URL().String(), res, body); err != nil {
+		e.log.WithError(err).Warn("Failed to write WARC response record")
+	}
+	// WriteResponse replaces res.Body with a fresh reader over body as a side
+	// effect of dumping the response; restore it here so the rest of the
+	// pipeline can still read it from the start.
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return res, nil
 }
 
 // Filter implements gocrawl.Extender.Filter
@@ -122,10 +248,15 @@ func (e *Extender) Filter(ctx *gocrawl.URLContext, isVisited bool) bool {
 		}
 	}
 
-	// Check if the fragmentless (and possibly queryless) URL matches the URL of
-	// an article that has already been saved in the database. Only check if the
-	// URL is in the map, we don't actually care about the value attached.
-	_, inMap := e.visitedArticles[ctx.URL().String()]
+	// Check if the fragmentless (and possibly queryless) URL is already known
+	// to the frontier, whether it has been fetched, is still pending, or has
+	// been given up on. gocrawl runs one worker goroutine per host, and
+	// related-asset harvesting routinely points Filter/Enqueued at several
+	// hosts for the same website (a page and the CDN its images/scripts are
+	// served from), so seen needs a lock shared between them.
+	e.seenMu.Lock()
+	inMap := e.seen[ctx.URL().String()]
+	e.seenMu.Unlock()
 
 	// Check if the URL matches with the exclude and restrict filters. To be
 	// accepted, a URL must pass the restrict filter and not pass the exclude one.
@@ -140,9 +271,113 @@ func (e *Extender) Filter(ctx *gocrawl.URLContext, isVisited bool) bool {
 		}
 	}
 
+	// A URL tagged as related (an asset referenced by an article rather than a
+	// navigational link) is only ever in scope if the website opted into
+	// IncludeRelated: it then bypasses the restrict filter, since it's
+	// inherently in scope for the page that referenced it, but must still
+	// pass the exclude filter. If the website didn't opt in, reject it
+	// outright, regardless of what the restrict/exclude filters would
+	// otherwise decide for it (harvestLinks shouldn't emit these URLs in the
+	// first place when the flag is off, but Filter enforces it either way).
+	if tag, ok := ctx.State.(linkScope); ok && tag == scopeRelated {
+		if !e.website.IncludeRelated {
+			return false
+		}
+
+		matchRestrict = true
+	}
+
 	return !isVisited && !inMap && (matchRestrict && !matchExclude)
 }
 
+// Enqueued implements gocrawl.Extender.Enqueued
+// Records the URL as pending in the frontier store as soon as gocrawl accepts
+// it, so a crash between now and the matching Visit/Error call still leaves a
+// trace of the URL to resume from.
+func (e *Extender) Enqueued(ctx *gocrawl.URLContext) {
+	target := ctx.URL().String()
+	e.seenMu.Lock()
+	e.seen[target] = true
+	e.seenMu.Unlock()
+
+	if err := e.frontier.MarkPending(e.website.Identifier, target); err != nil {
+		e.log.WithError(err).Warn("Failed to record pending URL in the frontier")
+	}
+}
+
+// linkScope tags a URL discovered while visiting a page as either primary
+// (a navigational <a href> link that could lead to more articles) or related
+// (an asset the page embeds, such as a stylesheet, script or image). It is
+// stored in gocrawl's URLContext.State so Filter can tell the two apart.
+type linkScope int
+
+const (
+	scopePrimary linkScope = iota
+	scopeRelated
+)
+
+// cssURLPattern matches every url(...) reference in a <style> block's text,
+// so assets pulled in through @import and property values (background,
+// @font-face src, ...) can be tagged as related resources too. It's only ever
+// run over <style> text, so it doesn't need to anchor on @import/: first;
+// doing so previously made it greedily swallow everything up to the last
+// url(...) on a line, silently dropping every match but the last when several
+// appear in one declaration (or one spanning several lines).
+var cssURLPattern = regexp.MustCompile(`url\(["']?([^'"\)]+)["']?\)`)
+
+// harvestLinks walks doc for navigational links (<a href>), and, if
+// includeRelated is set, embedded assets too (<link href>, <img src>,
+// <script src>, and CSS url(...) references), resolving each to an absolute
+// URL and tagging it with its linkScope. The resulting map is returned as
+// Visit's harvested value, so gocrawl enqueues every discovered URL with its
+// tag already attached to its context. Related assets are only emitted when
+// includeRelated is true: a website that never opted into IncludeRelated
+// shouldn't have those URLs enqueued in the first place, regardless of what
+// Filter would otherwise decide for them.
+func harvestLinks(doc *goquery.Document, includeRelated bool) map[*url.URL]interface{} {
+	links := make(map[*url.URL]interface{})
+
+	add := func(raw string, tag linkScope) {
+		if raw == "" {
+			return
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return
+		}
+		links[doc.Url.ResolveReference(u)] = tag
+	}
+
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		add(href, scopePrimary)
+	})
+
+	if !includeRelated {
+		return links
+	}
+
+	doc.Find("link[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		add(href, scopeRelated)
+	})
+	doc.Find("img[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		add(src, scopeRelated)
+	})
+	doc.Find("script[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		add(src, scopeRelated)
+	})
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		for _, m := range cssURLPattern.FindAllStringSubmatch(s.Text(), -1) {
+			add(m[1], scopeRelated)
+		}
+	})
+
+	return links
+}
+
 // Visit implements gocrawl.Extender.Visit
 // Parses a web page to check if it contains a news item, and if so extract all
 // data available and save it in the database. Also manipulates the content to
@@ -159,6 +394,22 @@ func (e *Extender) Visit(ctx *gocrawl.URLContext, res *http.Response, doc *goque
 		Kind: gocrawl.CekParseBody,
 	}
 
+	// A related resource (stylesheet, script, image, ...) is embedded in the
+	// article that referenced it and is never itself a new frontier: don't
+	// harvest further links from it, whether or not it turns out to parse as
+	// an article.
+	tag, hasTag := ctx.State.(linkScope)
+	isRelated := hasTag && tag == scopeRelated
+
+	// Non-HTML related resources (images, scripts, ...) have no goquery
+	// document to inspect; there's nothing to extract and, as related URLs,
+	// they don't harvest further links either way. The resource itself was
+	// fetched fine, so record it as such.
+	if doc == nil {
+		e.markFetched(ctx)
+		return nil, false
+	}
+
 	var err error
 	var description, author *string
 	var contentNodes, titleNodes, dateNodes *goquery.Selection
@@ -175,6 +426,35 @@ func (e *Extender) Visit(ctx *gocrawl.URLContext, res *http.Response, doc *goque
 	// least one, only the first match will be used.
 	// If one of theses requirements isn't met, it means the page isn't an article.
 	if len(contentNodes.Nodes) != 1 || len(titleNodes.Nodes) != 1 || len(dateNodes.Nodes) == 0 {
+		if e.website.Fallback != "" && e.website.Fallback != FallbackNone {
+			if fb, strategy, ok := extractFallback(doc, e.website.Fallback); ok {
+				content := fb.Content
+				if fb.Thumbnail != "" {
+					// fb.Thumbnail comes straight from the page's own
+					// JSON-LD/OpenGraph metadata, so it must be escaped
+					// before going into an attribute, same as the
+					// CSS-selector path gets for free by going through
+					// goquery's DOM serialization instead of string format.
+					content = fmt.Sprintf(`<img src="%s">`, htmlescape.EscapeString(fb.Thumbnail)) + content
+				}
+
+				e.log.WithFields(logrus.Fields{
+					"page_url": ctx.URL().String(),
+					"strategy": strategy,
+				}).Info("Extracted article via structured-data fallback")
+
+				if e.saveArticle(ctx, crawlError, fb.Title, content, fb.Description, fb.Author, fb.Date) {
+					e.markFetched(ctx)
+				}
+
+				if isRelated {
+					return nil, false
+				}
+
+				return harvestLinks(doc, e.website.IncludeRelated), true
+			}
+		}
+
 		e.log.WithFields(logrus.Fields{
 			"content_matches": len(contentNodes.Nodes),
 			"title_matches":   len(titleNodes.Nodes),
@@ -182,7 +462,15 @@ func (e *Extender) Visit(ctx *gocrawl.URLContext, res *http.Response, doc *goque
 			"page_url":        ctx.URL().String(),
 		}).Debug("Current page isn't an article")
 
-		return nil, true
+		// The page was fetched fine, it just isn't an article by this
+		// website's configuration; there's nothing to persist.
+		e.markFetched(ctx)
+
+		if isRelated {
+			return nil, false
+		}
+
+		return harvestLinks(doc, e.website.IncludeRelated), true
 	}
 
 	// Look for optional data, starting with the description.
@@ -257,21 +545,85 @@ func (e *Extender) Visit(ctx *gocrawl.URLContext, res *http.Response, doc *goque
 		e.Error(crawlError)
 	}
 
+	if e.saveArticle(ctx, crawlError, title, content, description, author, dateTime) {
+		e.markFetched(ctx)
+	}
+
+	if isRelated {
+		return nil, false
+	}
+
+	return harvestLinks(doc, e.website.IncludeRelated), true
+}
+
+// markFetched records ctx's URL as successfully fetched in the frontier, so a
+// restarted crawl won't queue it again. It is only called once Visit knows
+// the page didn't need saving, or that saveArticle actually persisted it:
+// a save failure gets its own, retryable outcome instead (see saveArticle).
+func (e *Extender) markFetched(ctx *gocrawl.URLContext) {
+	if err := e.frontier.MarkFetched(e.website.Identifier, ctx.URL().String()); err != nil {
+		e.log.WithError(err).Warn("Failed to record fetched URL in the frontier")
+	}
+}
+
+// saveArticle logs and persists an extracted article to the database,
+// reporting any failure through crawlError. It is shared by the CSS-selector
+// extraction path and the structured-data fallback path in Visit. It returns
+// whether every sink succeeded, so the caller can decide whether the URL is
+// done with (see markFetched) or should be retried instead.
+func (e *Extender) saveArticle(
+	ctx *gocrawl.URLContext, crawlError *gocrawl.CrawlError,
+	title, content string, description, author *string, dateTime time.Time,
+) bool {
 	e.log.WithFields(logrus.Fields{
 		"title": title,
 		"date":  dateTime.String(),
 	}).Info("Saving article")
 
-	// Saving the item in the database.
-	if err = e.db.SaveArticle(
-		e.website.Identifier, ctx.URL(), title,
-		description, content, author, dateTime,
-	); err != nil {
-		crawlError.Err = err
+	article := sink.Article{
+		WebsiteID:   e.website.Identifier,
+		URL:         ctx.URL(),
+		Title:       title,
+		Description: description,
+		Content:     content,
+		Author:      author,
+		PublishedAt: dateTime,
+		CrawledAt:   time.Now().UTC(),
+	}
+
+	// Fan the article out to every configured sink, and aggregate failures
+	// into a single error, so one failing sink (e.g. an unreachable webhook)
+	// doesn't keep the others (e.g. the database) from being written to.
+	var failures []string
+	for _, s := range e.sinks {
+		if err := s.Save(context.Background(), article); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		crawlError.Err = fmt.Errorf(
+			"%d sink(s) failed to save the article: %s",
+			len(failures), strings.Join(failures, "; "),
+		)
 		e.Error(crawlError)
+
+		// A save failure is a genuine reason to retry this URL: unlike the
+		// cosmetic post-fetch errors Error() otherwise ignores (see its
+		// CekParseBody exclusion), nothing was persisted for this article.
+		// Mark it failed directly, bypassing that exclusion, so its cause and
+		// retry count are recorded in the frontier and it gets reseeded
+		// instead of being silently dropped.
+		if ferr := e.frontier.MarkFailed(
+			e.website.Identifier, ctx.URL().String(), crawlError.Err.Error(), e.maxRetries,
+		); ferr != nil {
+			e.log.WithError(ferr).Warn("Failed to record failed URL in the frontier")
+		}
+
+		return false
 	}
 
-	return nil, true
+	return true
 }
 
 // Error implements gocrawl.Extender.Error
@@ -279,6 +631,28 @@ func (e *Extender) Visit(ctx *gocrawl.URLContext, res *http.Response, doc *goque
 // goroutine, according to the data provided.
 func (e *Extender) Error(err *gocrawl.CrawlError) {
 	if err != nil {
+		// Only a genuine fetch failure should flip the URL back to pending in
+		// the frontier. Visit raises every one of its own errors (a malformed
+		// <a>/<img> attribute, a content.Html() failure, a bad date, a failing
+		// sink, ...) with Kind set to CekParseBody, and Visit records the URL's
+		// outcome in the frontier itself (markFetched once the article is
+		// saved, MarkFailed from saveArticle if a sink fails); re-marking it
+		// failed here would send an already-saved article back into the retry
+		// queue and, on restart, re-submit it to non-idempotent sinks like the
+		// JSONL file or the webhook.
+		if err.Ctx != nil && err.Kind != gocrawl.CekParseBody {
+			cause := err.Kind.String()
+			if err.Err != nil {
+				cause = err.Err.Error()
+			}
+
+			if ferr := e.frontier.MarkFailed(
+				e.website.Identifier, err.Ctx.URL().String(), cause, e.maxRetries,
+			); ferr != nil {
+				e.log.WithError(ferr).Warn("Failed to record failed URL in the frontier")
+			}
+		}
+
 		if err.Ctx == nil {
 			if err.Err == nil {
 				e.errChan <- fmt.Errorf(
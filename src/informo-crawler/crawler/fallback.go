@@ -0,0 +1,229 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crawler
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Fallback strategy identifiers for config.Website.Fallback. "auto" tries
+// jsonld first and falls back to opengraph if it doesn't yield an article.
+const (
+	FallbackNone      = "none"
+	FallbackJSONLD    = "jsonld"
+	FallbackOpenGraph = "opengraph"
+	FallbackAuto      = "auto"
+)
+
+// fallbackArticle holds the fields recovered by a structured-data fallback
+// strategy, mirroring what the CSS-selector extraction in Visit produces.
+type fallbackArticle struct {
+	Title       string
+	Date        time.Time
+	Content     string
+	Description *string
+	Author      *string
+	Thumbnail   string
+}
+
+// jsonLDArticleTypes are the schema.org @type values accepted as an article.
+var jsonLDArticleTypes = map[string]bool{
+	"NewsArticle": true,
+	"Article":     true,
+	"BlogPosting": true,
+}
+
+// extractFallback attempts to recover an article's fields from structured
+// metadata when the website's CSS selectors didn't match, following the
+// strategy configured for the website. It returns the name of the strategy
+// that succeeded, for observability, along with whether extraction yielded a
+// usable article: title, date and a non-empty content body must all resolve.
+func extractFallback(doc *goquery.Document, strategy string) (*fallbackArticle, string, bool) {
+	if strategy == FallbackJSONLD || strategy == FallbackAuto {
+		if a, ok := extractJSONLD(doc); ok {
+			return a, FallbackJSONLD, true
+		}
+	}
+
+	if strategy == FallbackOpenGraph || strategy == FallbackAuto {
+		if a, ok := extractOpenGraph(doc); ok {
+			return a, FallbackOpenGraph, true
+		}
+	}
+
+	return nil, "", false
+}
+
+// extractJSONLD walks every <script type="application/ld+json"> block looking
+// for a NewsArticle, Article or BlogPosting node, including ones nested inside
+// an @graph array, and returns the first one found.
+func extractJSONLD(doc *goquery.Document) (*fallbackArticle, bool) {
+	var found *fallbackArticle
+
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var raw interface{}
+		if err := json.Unmarshal([]byte(s.Text()), &raw); err != nil {
+			// Malformed block; keep looking at the next one.
+			return true
+		}
+
+		if a, ok := articleFromJSONLD(raw); ok {
+			found = a
+			return false
+		}
+
+		return true
+	})
+
+	return found, found != nil
+}
+
+// articleFromJSONLD recursively looks for an article node in a decoded
+// JSON-LD value, descending into top-level arrays and @graph arrays.
+func articleFromJSONLD(raw interface{}) (*fallbackArticle, bool) {
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if a, ok := articleFromJSONLD(item); ok {
+				return a, true
+			}
+		}
+	case map[string]interface{}:
+		if graph, ok := v["@graph"].([]interface{}); ok {
+			if a, ok := articleFromJSONLD(graph); ok {
+				return a, true
+			}
+		}
+
+		if !isJSONLDArticleType(v["@type"]) {
+			return nil, false
+		}
+
+		title, _ := v["headline"].(string)
+		content, _ := v["articleBody"].(string)
+		dateStr, _ := v["datePublished"].(string)
+		if title == "" || content == "" || dateStr == "" {
+			return nil, false
+		}
+
+		date, err := time.Parse(time.RFC3339, dateStr)
+		if err != nil {
+			return nil, false
+		}
+
+		a := &fallbackArticle{Title: title, Date: date, Content: content}
+
+		if d, ok := v["description"].(string); ok && d != "" {
+			a.Description = &d
+		}
+		if author, ok := v["author"].(map[string]interface{}); ok {
+			if name, ok := author["name"].(string); ok && name != "" {
+				a.Author = &name
+			}
+		}
+		switch img := v["image"].(type) {
+		case string:
+			a.Thumbnail = img
+		case map[string]interface{}:
+			if u, ok := img["url"].(string); ok {
+				a.Thumbnail = u
+			}
+		}
+
+		return a, true
+	}
+
+	return nil, false
+}
+
+// isJSONLDArticleType reports whether a JSON-LD @type value (a string, or an
+// array of strings) contains one of jsonLDArticleTypes.
+func isJSONLDArticleType(t interface{}) bool {
+	switch v := t.(type) {
+	case string:
+		return jsonLDArticleTypes[v]
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && jsonLDArticleTypes[s] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// extractOpenGraph falls back to OpenGraph meta tags plus the largest
+// <article> or <main> element's inner HTML for content, for sites that don't
+// publish JSON-LD.
+func extractOpenGraph(doc *goquery.Document) (*fallbackArticle, bool) {
+	title := metaProperty(doc, "og:title")
+	dateStr := metaProperty(doc, "article:published_time")
+	if title == "" || dateStr == "" {
+		return nil, false
+	}
+
+	date, err := time.Parse(time.RFC3339, dateStr)
+	if err != nil {
+		return nil, false
+	}
+
+	content := largestContentElement(doc)
+	if content == "" {
+		return nil, false
+	}
+
+	a := &fallbackArticle{Title: title, Date: date, Content: content}
+
+	if d := metaProperty(doc, "og:description"); d != "" {
+		a.Description = &d
+	}
+	if img := metaProperty(doc, "og:image"); img != "" {
+		a.Thumbnail = img
+	}
+
+	return a, true
+}
+
+// metaProperty returns the content attribute of the <meta property="..."> tag
+// matching property, or the empty string if it isn't present.
+func metaProperty(doc *goquery.Document, property string) string {
+	content, _ := doc.Find(`meta[property="` + property + `"]`).Attr("content")
+	return content
+}
+
+// largestContentElement returns the inner HTML of the <article> or <main>
+// element with the most content on the page, used as a last-resort content
+// body when OpenGraph metadata doesn't carry the full article text.
+func largestContentElement(doc *goquery.Document) string {
+	var best string
+
+	doc.Find("article, main").Each(func(_ int, s *goquery.Selection) {
+		html, err := s.Html()
+		if err != nil {
+			return
+		}
+		if len(html) > len(best) {
+			best = html
+		}
+	})
+
+	return strings.TrimSpace(best)
+}
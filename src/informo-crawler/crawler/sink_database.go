@@ -0,0 +1,35 @@
+// Copyright 2018 Informo core team <core@informo.network>
+//
+// Licensed under the GNU Affero General Public License, Version 3.0
+// (the "License"); you may not use this file except in compliance with the
+// License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crawler
+
+import (
+	"context"
+
+	"common/database"
+	"common/sink"
+)
+
+// databaseSink adapts database.Database's existing SaveArticle method to the
+// sink.ArticleSink interface, so the database keeps being written to exactly
+// as before, but alongside whatever other sinks a website is configured with.
+type databaseSink struct {
+	db *database.Database
+}
+
+// Save implements sink.ArticleSink.Save
+func (s *databaseSink) Save(ctx context.Context, a sink.Article) error {
+	return s.db.SaveArticle(a.WebsiteID, a.URL, a.Title, a.Description, a.Content, a.Author, a.PublishedAt)
+}